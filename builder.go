@@ -0,0 +1,72 @@
+package tinyrbac
+
+import "slices"
+
+// Builder creates an RBAC instance programmatically as an alternative to
+// config files. Roles, resources and grants are accumulated into a config
+// which goes through the same validation as a config file on Build.
+//
+//	b := NewBuilder()
+//	b.Role("Admin").Grant("posts", "GET", "POST")
+//	rbac, err := b.Build()
+type Builder struct {
+	c         config
+	resources map[string]bool
+	opts      []Option
+}
+
+// RoleBuilder adds grants to a role of a Builder.
+type RoleBuilder struct {
+	b *Builder
+	// idx is the index of the role in the config. A pointer to the role
+	// cannot be held since adding roles may reallocate the roles slice.
+	idx int
+}
+
+// NewBuilder returns an empty Builder. The options are applied on Build.
+func NewBuilder(opts ...Option) *Builder {
+	return &Builder{
+		resources: make(map[string]bool),
+		opts:      opts,
+	}
+}
+
+// Role returns the RoleBuilder of the role 'name', adding the role
+// when it does not exist yet.
+func (b *Builder) Role(name string) *RoleBuilder {
+	idx := slices.IndexFunc(b.c.Roles, func(r role) bool {
+		return r.Name == name
+	})
+	if idx == -1 {
+		b.c.Roles = append(b.c.Roles, role{Name: name})
+		idx = len(b.c.Roles) - 1
+	}
+
+	return &RoleBuilder{b: b, idx: idx}
+}
+
+// Grant gives the role access to perform 'actions' on 'resourceName'. The
+// resource is added to the resources of the Builder unless it is the
+// wildcard resource.
+func (rb *RoleBuilder) Grant(resourceName string, actions ...string) *RoleBuilder {
+	if resourceName != "" && resourceName != allResources && !rb.b.resources[resourceName] {
+		rb.b.resources[resourceName] = true
+		rb.b.c.Resources = append(rb.b.c.Resources, resourceName)
+	}
+
+	r := &rb.b.c.Roles[rb.idx]
+	r.Resources = append(r.Resources, resource{
+		Name: resourceName,
+		// Building the access map modifies the actions in place,
+		// so the caller's slice must not be used directly.
+		Actions: slices.Clone(actions),
+	})
+
+	return rb
+}
+
+// Build validates the accumulated config and creates an RBAC instance
+// from it. An error is returned when the config is invalid.
+func (b *Builder) Build() (*Rbac, error) {
+	return newFromConfig(&b.c, newOptions(b.opts))
+}
@@ -0,0 +1,92 @@
+package tinyrbac
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Builder(t *testing.T) {
+	b := NewBuilder()
+	b.Role("Admin").Grant("*", "GET", "POST", "PUT", "PATCH", "DELETE")
+	b.Role("Editor").
+		Grant("posts", "GET", "POST", "PUT").
+		Grant("comments", "GET", "DELETE")
+	b.Role("Reader").Grant("posts", "GET")
+	b.Role("Reader").Grant("comments", "GET")
+
+	r, err := b.Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Admin", "Editor", "Reader"}, r.roleIdxMap[:3])
+	assert.Equal(t, []string{"comments", "posts"}, r.resourceIdxMap[:2])
+
+	testcases := []struct {
+		role           string
+		resource       string
+		action         string
+		expectedAccess bool
+	}{
+		{role: "Admin", resource: "posts", action: "DELETE", expectedAccess: true},
+		{role: "Admin", resource: "comments", action: "PATCH", expectedAccess: true},
+		{role: "Editor", resource: "posts", action: "PUT", expectedAccess: true},
+		{role: "Editor", resource: "posts", action: "DELETE", expectedAccess: false},
+		{role: "Editor", resource: "comments", action: "DELETE", expectedAccess: true},
+		{role: "Editor", resource: "comments", action: "POST", expectedAccess: false},
+		{role: "Reader", resource: "posts", action: "GET", expectedAccess: true},
+		{role: "Reader", resource: "comments", action: "GET", expectedAccess: true},
+		{role: "Reader", resource: "posts", action: "POST", expectedAccess: false},
+	}
+
+	for _, tt := range testcases {
+		t.Run(fmt.Sprintf("%s %s %s", tt.role, tt.action, tt.resource), func(t *testing.T) {
+			access, err := r.Check(tt.role, tt.resource, tt.action)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAccess, access)
+		})
+	}
+}
+
+func Test_Builder_errors(t *testing.T) {
+	t.Run("roles exceeded", func(t *testing.T) {
+		b := NewBuilder()
+		for i := range maxRoles + 1 {
+			b.Role(unique2Char[i]).Grant("posts", "GET")
+		}
+
+		r, err := b.Build()
+		require.Error(t, err)
+		assert.Equal(t, fmt.Sprintf("validate config: roles exceeded: maximum %d but config has %d", maxRoles, maxRoles+1), err.Error())
+		require.Nil(t, r)
+	})
+
+	t.Run("empty builder", func(t *testing.T) {
+		r, err := NewBuilder().Build()
+		require.Error(t, err)
+		assert.Equal(t, "validate config: "+ErrNoResources.Error(), err.Error())
+		require.Nil(t, r)
+	})
+
+	t.Run("role without grants", func(t *testing.T) {
+		b := NewBuilder()
+		b.Role("Admin").Grant("posts", "GET")
+		b.Role("Reader")
+
+		r, err := b.Build()
+		require.Error(t, err)
+		assert.Equal(t, "validate config: empty resources: not defined for role Reader", err.Error())
+		require.Nil(t, r)
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		b := NewBuilder()
+		b.Role("Admin").Grant("posts", "TRACE")
+
+		r, err := b.Build()
+		require.Error(t, err)
+		assert.Equal(t, "build role Admin: unknown action: TRACE", err.Error())
+		require.Nil(t, r)
+	})
+}
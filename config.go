@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
 
 	"gopkg.in/yaml.v3"
 )
@@ -15,20 +17,31 @@ const (
 )
 
 type config struct {
-	Description string
+	Description string `json:",omitempty" yaml:",omitempty"`
 	Roles       []role
 	Resources   []string
+	Deny        []deny            `json:",omitempty" yaml:",omitempty"`
+	Aliases     map[string]string `json:",omitempty" yaml:",omitempty"`
 }
 
 type role struct {
 	Name        string
-	Description string
+	Description string `json:",omitempty" yaml:",omitempty"`
 	Resources   []resource
 }
 
 type resource struct {
-	Name    string
-	Actions []string
+	Name        string
+	Description string `json:",omitempty" yaml:",omitempty"`
+	Actions     []string
+}
+
+// deny removes access for an action on a resource from a role. Denies
+// are applied after all the grants, so they take precedence over them.
+type deny struct {
+	Role     string
+	Resource string
+	Action   string
 }
 
 func newConfigFromJson(path string) (*config, error) {
@@ -77,21 +90,155 @@ func newConfigFromYaml(path string) (*config, error) {
 	return &c, nil
 }
 
+// toConfig rebuilds a config from the access map of 'r'.
+//
+// The config is derived from the effective grants, so it is equivalent to
+// but not necessarily identical with the config 'r' was built from. Access
+// on all the resources for an action is exported as a wildcard resource.
+// Resource entries with a description are always exported so that the
+// description is kept, even when they have no access of their own.
+// A role without any access is exported with an entry that has no
+// actions, so that the config stays valid.
+func (r *Rbac) toConfig() *config {
+	c := &config{Description: r.description, Aliases: maps.Clone(r.aliases)}
+	for _, resource := range r.resourceIdxMap {
+		if resource != "" {
+			c.Resources = append(c.Resources, resource)
+		}
+	}
+
+	for roleIdx, roleName := range r.roleIdxMap {
+		if roleName == "" {
+			break
+		}
+
+		grants := make(map[string][]string)
+		for offset, action := range r.actionIdxMap {
+			if action == "" {
+				continue
+			}
+			set := r.accessMap[roleIdx*maxActions+offset]
+			wildcard := set == allResourceAccess
+			if wildcard {
+				grants[allResources] = append(grants[allResources], action)
+			}
+			for resourceIdx, resource := range r.resourceIdxMap {
+				// Resources covered by the wildcard are only exported
+				// when they have a description that would be lost otherwise.
+				if wildcard && !r.hasResourceDescription(roleName, resource) {
+					continue
+				}
+				if resource != "" && set&(1<<resourceIdx) != 0 {
+					grants[resource] = append(grants[resource], action)
+				}
+			}
+		}
+
+		ro := role{Name: roleName, Description: r.roleDescriptions[roleIdx]}
+		for _, name := range append([]string{allResources}, c.Resources...) {
+			actions, ok := grants[name]
+			if ok || r.hasResourceDescription(roleName, name) {
+				ro.Resources = append(ro.Resources, resource{
+					Name:        name,
+					Description: r.resourceDescriptions[roleResource{roleName, name}],
+					Actions:     actions,
+				})
+			}
+		}
+		// Validation requires every role to have a resource entry, so a
+		// role without any access gets one with no actions.
+		if len(ro.Resources) == 0 {
+			ro.Resources = append(ro.Resources, resource{Name: c.Resources[0], Actions: []string{}})
+		}
+		c.Roles = append(c.Roles, ro)
+	}
+
+	return c
+}
+
+// hasResourceDescription reports whether the resource entry of a role has a description.
+func (r *Rbac) hasResourceDescription(role, resource string) bool {
+	_, ok := r.resourceDescriptions[roleResource{role, resource}]
+	return ok
+}
+
+// validateResourceNames checks that none of the entries in the
+// resources list are empty. It is only used in strict mode since
+// validate skips empty resource names.
+func (c *config) validateResourceNames() error {
+	for i, r := range c.Resources {
+		if r == "" {
+			return fmt.Errorf("empty resource name at index %d", i)
+		}
+	}
+	return nil
+}
+
+// resolveResource returns the resource 'name' is an alias of,
+// or 'name' itself when it is not an alias.
+func (c *config) resolveResource(name string) string {
+	if target, ok := c.Aliases[name]; ok {
+		return target
+	}
+	return name
+}
+
 // validate checks if the config fields are valid and consistent.
 //
 // Validations are done in the below order. An error is returned for the following:
 // No resources.
 // Resources greater than max resources.
+// No alias name.
+// Wildcard alias.
+// Alias shadowing a resource.
+// Alias of an alias, which covers alias cycles.
+// Alias of an undefined resource.
 // No roles.
 // No role name.
 // No resources for a role.
 // Undefined resource provided for a role.
 // Roles greater than max roles.
+// No role for a deny.
+// Undefined role provided for a deny.
+// Undefined resource provided for a deny.
+// No action for a deny.
+// Wildcard deny of an action the role is allowed on all resources.
 // No resource name.
 // TODO: Action validation
 func (c *config) validate() error {
+	resources, err := c.validateResources()
+	if err != nil {
+		return err
+	}
+
+	if len(c.Roles) == 0 {
+		return ErrNoRoles
+	}
+
+	// Roles are unique because json unmarshaling
+	// will overwrite duplicate entries. Hence, a map
+	// filtering is not required unlike resources.
+	roles := newRoleSummary()
+	for i, role := range c.Roles {
+		if err := c.validateRole(i, role, resources); err != nil {
+			return err
+		}
+		roles.add(role)
+	}
+
+	if roles.count > maxRoles {
+		return fmt.Errorf("roles exceeded: maximum %d but config has %d", maxRoles, len(c.Roles))
+
+	}
+
+	return c.validateDeny(resources, roles)
+}
+
+// validateResources validates the resources and aliases of the config
+// and returns the set of defined resources.
+func (c *config) validateResources() (map[string]bool, error) {
 	if len(c.Resources) == 0 {
-		return ErrNoResources
+		return nil, ErrNoResources
 	}
 
 	resources := make(map[string]bool)
@@ -103,39 +250,128 @@ func (c *config) validate() error {
 	}
 
 	if len(resources) > maxResources {
-		return fmt.Errorf("resources exceeded: maximum %d but config has %d", maxResources, len(c.Resources))
+		return nil, fmt.Errorf("resources exceeded: maximum %d but config has %d", maxResources, len(c.Resources))
 	}
 
-	if len(c.Roles) == 0 {
-		return ErrNoRoles
+	// Aliases must point directly at a resource so that a
+	// single lookup is enough to resolve them.
+	for _, alias := range slices.Sorted(maps.Keys(c.Aliases)) {
+		target := c.Aliases[alias]
+		if alias == "" {
+			return nil, fmt.Errorf("empty alias: name not defined for %s", target)
+		}
+
+		// The wildcard resource always means all the resources.
+		if alias == allResources {
+			return nil, fmt.Errorf("wildcard alias: %s cannot be an alias of %s", allResources, target)
+		}
+
+		if resources[alias] {
+			return nil, fmt.Errorf("alias shadows resource: %s is defined in resources", alias)
+		}
+
+		if _, ok := c.Aliases[target]; ok {
+			return nil, fmt.Errorf("alias chain: %s points at alias %s", alias, target)
+		}
+
+		if !resources[target] {
+			return nil, fmt.Errorf("undefined resource: %s for alias %s: %s not defined in resources", target, alias, target)
+		}
 	}
 
-	// Roles are unique because json unmarshaling
-	// will overwrite duplicate entries. Hence, a map
-	// filtering is not required unlike resources.
-	roleCount := 0
-	for i, role := range c.Roles {
-		if role.Name == "" {
-			return fmt.Errorf("empty role: name not defined at index %d", i)
+	return resources, nil
+}
+
+// validateRole validates the role at index 'i' of the config against the
+// defined resources.
+func (c *config) validateRole(i int, role role, resources map[string]bool) error {
+	if role.Name == "" {
+		return fmt.Errorf("empty role: name not defined at index %d", i)
+	}
+
+	if len(role.Resources) == 0 {
+		return fmt.Errorf("empty resources: not defined for role %s", role.Name)
+	}
+
+	for _, re := range role.Resources {
+		if ok := resources[c.resolveResource(re.Name)]; re.Name != allResources && !ok {
+			return fmt.Errorf("undefined resource: %s for role %s: %s not defined in resources", re.Name, role.Name, re.Name)
 		}
+	}
 
-		if len(role.Resources) == 0 {
-			return fmt.Errorf("empty resources: not defined for role %s", role.Name)
+	return nil
+}
+
+// validateDeny validates the denies of the config against the
+// defined resources and the validated roles.
+func (c *config) validateDeny(resources map[string]bool, roles *roleSummary) error {
+	for i, d := range c.Deny {
+		if d.Role == "" {
+			return fmt.Errorf("empty deny: role not defined at index %d", i)
 		}
 
-		for _, re := range role.Resources {
-			if ok := resources[re.Name]; re.Name != allResources && !ok {
-				return fmt.Errorf("undefined resource: %s for role %s: %s not defined in resources", re.Name, role.Name, re.Name)
-			}
+		if !roles.names[d.Role] {
+			return fmt.Errorf("undefined role: %s for deny at index %d: %s not defined in roles", d.Role, i, d.Role)
+		}
+
+		if ok := resources[c.resolveResource(d.Resource)]; d.Resource != allResources && !ok {
+			return fmt.Errorf("undefined resource: %s for deny at index %d: %s not defined in resources", d.Resource, i, d.Resource)
 		}
 
-		roleCount++
+		if d.Action == "" {
+			return fmt.Errorf("empty deny: action not defined at index %d", i)
+		}
+
+		// Deny wins at runtime, but allowing and denying an action on
+		// all the resources at once is a mistake rather than intent.
+		if d.Resource == allResources && slices.Contains(roles.wildcardActions[d.Role], d.Action) {
+			return fmt.Errorf("contradictory wildcard: role %s both allows and denies %s on %s", d.Role, d.Action, allResources)
+		}
 	}
 
-	if roleCount > maxRoles {
-		return fmt.Errorf("roles exceeded: maximum %d but config has %d", maxRoles, len(c.Roles))
+	return nil
+}
 
+// validateDenyActions checks that the denied actions are known to 'actionOffset',
+// or to the HTTP method mapping when it is nil. It is kept apart from validate
+// since the action mapping is an option of the RBAC instance, not part of the config.
+func (c *config) validateDenyActions(actionOffset func(action string) (int, bool)) error {
+	if actionOffset == nil {
+		actionOffset = httpActionOffset
+	}
+
+	for i, d := range c.Deny {
+		if _, ok := actionOffset(d.Action); !ok {
+			return fmt.Errorf("unknown action: %s for deny at index %d", d.Action, i)
+		}
 	}
 
 	return nil
 }
+
+// roleSummary keeps what deny validation needs to know about the
+// roles, so that the roles themselves need not be retained.
+type roleSummary struct {
+	count int
+	names map[string]bool
+	// wildcardActions holds the actions each role is
+	// allowed to perform on all the resources.
+	wildcardActions map[string][]string
+}
+
+func newRoleSummary() *roleSummary {
+	return &roleSummary{
+		names:           make(map[string]bool),
+		wildcardActions: make(map[string][]string),
+	}
+}
+
+func (s *roleSummary) add(role role) {
+	s.count++
+	s.names[role.Name] = true
+	for _, re := range role.Resources {
+		if re.Name == allResources {
+			s.wildcardActions[role.Name] = append(s.wildcardActions[role.Name], re.Actions...)
+		}
+	}
+}
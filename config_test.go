@@ -341,6 +341,60 @@ func Test_validate(t *testing.T) {
 			wantErr:     true,
 			expectedErr: fmt.Sprintf("resources exceeded: maximum %d but config has %d", maxResources, len(unique2Char)),
 		},
+		{
+			name: "alias of undefined resource",
+			c: &config{
+				Resources: []string{"users"},
+				Aliases:   map[string]string{"accounts": "members"},
+			},
+			wantErr:     true,
+			expectedErr: "undefined resource: members for alias accounts: members not defined in resources",
+		},
+		{
+			name: "alias chain",
+			c: &config{
+				Resources: []string{"users"},
+				Aliases:   map[string]string{"accounts": "users", "profiles": "accounts"},
+			},
+			wantErr:     true,
+			expectedErr: "alias chain: profiles points at alias accounts",
+		},
+		{
+			name: "alias cycle",
+			c: &config{
+				Resources: []string{"users"},
+				Aliases:   map[string]string{"accounts": "profiles", "profiles": "accounts"},
+			},
+			wantErr:     true,
+			expectedErr: "alias chain: accounts points at alias profiles",
+		},
+		{
+			name: "alias shadows resource",
+			c: &config{
+				Resources: []string{"users", "accounts"},
+				Aliases:   map[string]string{"accounts": "users"},
+			},
+			wantErr:     true,
+			expectedErr: "alias shadows resource: accounts is defined in resources",
+		},
+		{
+			name: "empty alias name",
+			c: &config{
+				Resources: []string{"users"},
+				Aliases:   map[string]string{"": "users"},
+			},
+			wantErr:     true,
+			expectedErr: "empty alias: name not defined for users",
+		},
+		{
+			name: "wildcard alias",
+			c: &config{
+				Resources: []string{"users"},
+				Aliases:   map[string]string{"*": "users"},
+			},
+			wantErr:     true,
+			expectedErr: "wildcard alias: * cannot be an alias of users",
+		},
 		{
 			name: "no roles",
 			c: &config{
@@ -395,6 +449,74 @@ func Test_validate(t *testing.T) {
 			wantErr:     true,
 			expectedErr: "undefined resource: storage for role Auditor: storage not defined in resources",
 		},
+		{
+			name: "empty deny role",
+			c: &config{
+				Resources: []string{"instances"},
+				Roles:     []role{{Name: "Auditor", Resources: []resource{{Name: "instances"}}}},
+				Deny:      []deny{{Resource: "instances", Action: "GET"}},
+			},
+			wantErr:     true,
+			expectedErr: "empty deny: role not defined at index 0",
+		},
+		{
+			name: "undefined deny role",
+			c: &config{
+				Resources: []string{"instances"},
+				Roles:     []role{{Name: "Auditor", Resources: []resource{{Name: "instances"}}}},
+				Deny:      []deny{{Role: "Operator", Resource: "instances", Action: "GET"}},
+			},
+			wantErr:     true,
+			expectedErr: "undefined role: Operator for deny at index 0: Operator not defined in roles",
+		},
+		{
+			name: "undefined deny resource",
+			c: &config{
+				Resources: []string{"instances"},
+				Roles:     []role{{Name: "Auditor", Resources: []resource{{Name: "instances"}}}},
+				Deny:      []deny{{Role: "Auditor", Resource: "storage", Action: "GET"}},
+			},
+			wantErr:     true,
+			expectedErr: "undefined resource: storage for deny at index 0: storage not defined in resources",
+		},
+		{
+			name: "empty deny action",
+			c: &config{
+				Resources: []string{"instances"},
+				Roles:     []role{{Name: "Auditor", Resources: []resource{{Name: "instances"}}}},
+				Deny:      []deny{{Role: "Auditor", Resource: "*"}},
+			},
+			wantErr:     true,
+			expectedErr: "empty deny: action not defined at index 0",
+		},
+		{
+			name: "contradictory wildcard allow and deny",
+			c: &config{
+				Resources: []string{"instances"},
+				Roles: []role{
+					{
+						Name:      "Admin",
+						Resources: []resource{{Name: "*", Actions: []string{"GET", "POST"}}},
+					},
+				},
+				Deny: []deny{{Role: "Admin", Resource: "*", Action: "POST"}},
+			},
+			wantErr:     true,
+			expectedErr: "contradictory wildcard: role Admin both allows and denies POST on *",
+		},
+		{
+			name: "wildcard deny of action allowed on a resource",
+			c: &config{
+				Resources: []string{"instances"},
+				Roles: []role{
+					{
+						Name:      "Admin",
+						Resources: []resource{{Name: "instances", Actions: []string{"POST"}}},
+					},
+				},
+				Deny: []deny{{Role: "Admin", Resource: "*", Action: "POST"}},
+			},
+		},
 		{
 			name: "roles exceeded",
 			c: &config{
@@ -9,6 +9,7 @@ var (
 	ErrConfigFileNotProvided = errors.New("config file path is empty")
 	ErrNoResources           = errors.New("resources not provided")
 	ErrNoRoles               = errors.New("roles not provided")
+	ErrNoConfigAvailable     = errors.New("no config could be loaded")
 )
 
 func errConfigNotFound(filetype, path string, err error) error {
@@ -22,3 +23,11 @@ func errConfigRead(filetype, path string, err error) error {
 func errConfigUnmarshal(filetype, path string, err error) error {
 	return fmt.Errorf("unmarshal %s config %q: %w", filetype, path, err)
 }
+
+func errConfigFiletypeUnsupported(path string) error {
+	return fmt.Errorf("unsupported config file type %q", path)
+}
+
+func errActionOffsetCollision(known, action string, offset int) error {
+	return fmt.Errorf("action offset collision: %s and %s both map to %d", known, action, offset)
+}
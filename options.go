@@ -0,0 +1,37 @@
+package tinyrbac
+
+// Option configures how an RBAC instance is created.
+type Option func(*options)
+
+type options struct {
+	strictResources bool
+	actionOffset    func(action string) (int, bool)
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithStrictResources makes config validation fail on empty resource
+// names in the resources list instead of silently skipping them.
+func WithStrictResources() Option {
+	return func(o *options) {
+		o.strictResources = true
+	}
+}
+
+// WithActionOffsetFunc replaces the built-in HTTP method mapping with 'f'.
+// 'f' returns the offset of an action and whether the action is known.
+// Offsets must be non-negative and less than the maximum number of
+// actions, and distinct actions must not share an offset. Since there
+// is no vocabulary, only the actions used in the config are known to
+// Complement and WriteYamlConfig.
+func WithActionOffsetFunc(f func(action string) (int, bool)) Option {
+	return func(o *options) {
+		o.actionOffset = f
+	}
+}
@@ -0,0 +1,257 @@
+package tinyrbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// NewFromJsonConfigStream creates an RBAC instance from a JSON config file at
+// the given path, the same way as NewFromJsonConfig, without holding the whole
+// config in memory. An error is returned when the config file cannot be processed.
+//
+// The file is read three times. The first pass reads everything but the roles,
+// the second one validates the roles and the third one folds them into the access
+// map, decoding one role at a time. Validating all the roles before building any
+// of them reports the same error as NewFromJsonConfig for an invalid config.
+//
+// The roles are never held all at once, but the descriptions of the config, the
+// roles and their resource entries are retained like NewFromJsonConfig does, and
+// so are the denies and aliases. Peak memory is therefore bounded by the access
+// map and the largest role plus that retained text, rather than by the size of
+// the file. There is no YAML counterpart since the YAML decoder builds the whole
+// document before decoding any of it.
+func NewFromJsonConfigStream(path string, opts ...Option) (*Rbac, error) {
+	if path == "" {
+		return nil, fmt.Errorf("read config: %w", ErrConfigFileNotProvided)
+	}
+	o := newOptions(opts)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", errConfigNotFound(jsonConfigFiletype, path, err))
+	}
+	defer f.Close()
+
+	c := &config{}
+	// rolesKeys counts the roles keys, since only the last one
+	// is used when the key is repeated, like json.Unmarshal does.
+	rolesKeys := 0
+	err = walkJsonConfig(f, func(key string, dec *json.Decoder) error {
+		// Keys are matched case insensitively like json.Unmarshal does.
+		switch {
+		case strings.EqualFold(key, "roles"):
+			rolesKeys++
+			return decodeJsonRoles(dec, func(role) error { return nil })
+		case strings.EqualFold(key, "description"):
+			return dec.Decode(&c.Description)
+		case strings.EqualFold(key, "resources"):
+			return dec.Decode(&c.Resources)
+		case strings.EqualFold(key, "deny"):
+			return dec.Decode(&c.Deny)
+		case strings.EqualFold(key, "aliases"):
+			return dec.Decode(&c.Aliases)
+		default:
+			return dec.Decode(&json.RawMessage{})
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", errConfigUnmarshal(jsonConfigFiletype, path, err))
+	}
+
+	if o.strictResources {
+		if err := c.validateResourceNames(); err != nil {
+			return nil, fmt.Errorf("validate config: %w", err)
+		}
+	}
+	resources, err := c.validateResources()
+	if err != nil {
+		return nil, fmt.Errorf("validate config: %w", err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("read config: %w", errConfigRead(jsonConfigFiletype, path, err))
+	}
+
+	// Validation and build errors are kept apart from decoding
+	// errors since they are reported differently.
+	roles := newRoleSummary()
+	var validateErr error
+	err = walkJsonRoles(f, rolesKeys, func(ro role) error {
+		if err := c.validateRole(roles.count, ro, resources); err != nil {
+			validateErr = fmt.Errorf("validate config: %w", err)
+			return validateErr
+		}
+		// Stop at the first exceeding role so that the summary stays
+		// bounded. The count is of the roles read so far, not of all
+		// the roles in the config.
+		if roles.count == maxRoles {
+			validateErr = fmt.Errorf("validate config: roles exceeded: maximum %d but config has %d", maxRoles, roles.count+1)
+			return validateErr
+		}
+		roles.add(ro)
+		return nil
+	})
+	if validateErr != nil {
+		return nil, validateErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", errConfigUnmarshal(jsonConfigFiletype, path, err))
+	}
+
+	if roles.count == 0 {
+		return nil, fmt.Errorf("validate config: %w", ErrNoRoles)
+	}
+	if err := c.validateDeny(resources, roles); err != nil {
+		return nil, fmt.Errorf("validate config: %w", err)
+	}
+	if err := c.validateDenyActions(o.actionOffset); err != nil {
+		return nil, fmt.Errorf("validate config: %w", err)
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("read config: %w", errConfigRead(jsonConfigFiletype, path, err))
+	}
+
+	// Roles are indexed in the order they are read and
+	// sorted once all of them are known.
+	r := newRbac(c, o)
+	roleCount := 0
+	var buildErr error
+	err = walkJsonRoles(f, rolesKeys, func(ro role) error {
+		roleIdx := slices.Index(r.roleIdxMap[:roleCount], ro.Name)
+		if roleIdx == -1 {
+			roleIdx = roleCount
+			r.roleIdxMap[roleIdx] = ro.Name
+			roleCount++
+		}
+
+		if err := r.buildRole(roleIdx, ro); err != nil {
+			buildErr = err
+			return buildErr
+		}
+		return nil
+	})
+	if buildErr != nil {
+		return nil, buildErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", errConfigUnmarshal(jsonConfigFiletype, path, err))
+	}
+
+	r.sortRoles(roleCount)
+	if err := r.buildDeny(c.Deny); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// walkJsonConfig calls 'fn' for each top-level key of the JSON config read from
+// 'rd' with the decoder positioned at the value of the key. 'fn' must consume the value.
+// Like json.Unmarshal, anything but whitespace after the config is an error.
+func walkJsonConfig(rd io.Reader, fn func(key string, dec *json.Decoder) error) error {
+	dec := json.NewDecoder(rd)
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	// A null config is decoded as an empty one, like json.Unmarshal does.
+	if t != nil {
+		if t != json.Delim('{') {
+			return fmt.Errorf("unexpected %v: config is not an object", t)
+		}
+
+		for dec.More() {
+			t, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			if err := fn(t.(string), dec); err != nil {
+				return err
+			}
+		}
+
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+
+	t, err = dec.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("unexpected %v after top-level value", t)
+}
+
+// walkJsonRoles calls 'fn' for each role under the 'n'th roles key of the
+// JSON config read from 'rd'. The roles under the other roles keys are skipped.
+func walkJsonRoles(rd io.Reader, n int, fn func(role) error) error {
+	rolesKeys := 0
+	return walkJsonConfig(rd, func(key string, dec *json.Decoder) error {
+		if !strings.EqualFold(key, "roles") {
+			return dec.Decode(&json.RawMessage{})
+		}
+
+		rolesKeys++
+		if rolesKeys != n {
+			return decodeJsonRoles(dec, func(role) error { return nil })
+		}
+		return decodeJsonRoles(dec, fn)
+	})
+}
+
+// decodeJsonRoles decodes the roles array the decoder is positioned at and
+// calls 'fn' for each role as soon as it is decoded.
+func decodeJsonRoles(dec *json.Decoder, fn func(role) error) error {
+	t, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return nil
+	}
+	if t != json.Delim('[') {
+		return fmt.Errorf("unexpected %v: roles is not an array", t)
+	}
+
+	for dec.More() {
+		var ro role
+		if err := dec.Decode(&ro); err != nil {
+			return err
+		}
+		if err := fn(ro); err != nil {
+			return err
+		}
+	}
+
+	_, err = dec.Token()
+	return err
+}
+
+// sortRoles sorts the first 'n' roles by name along with their descriptions
+// and access map rows. It is needed when roles are indexed before all of them
+// are known, which buildRoleMapping does not allow for.
+func (r *Rbac) sortRoles(n int) {
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return strings.Compare(r.roleIdxMap[a], r.roleIdxMap[b])
+	})
+
+	unsorted := *r
+	for i, idx := range order {
+		r.roleIdxMap[i] = unsorted.roleIdxMap[idx]
+		r.roleDescriptions[i] = unsorted.roleDescriptions[idx]
+		copy(r.accessMap[i*maxActions:(i+1)*maxActions], unsorted.accessMap[idx*maxActions:(idx+1)*maxActions])
+	}
+}
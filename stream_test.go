@@ -0,0 +1,225 @@
+package tinyrbac
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// largeConfig generates a config with the maximum number of roles and
+// resources where every role has an entry with a long description for
+// every resource. Roles are generated in reverse order so that they
+// need to be sorted.
+func largeConfig() *config {
+	c := &config{
+		Description: strings.Repeat("policy ", 1000),
+		Resources:   unique2Char[:maxResources],
+		Aliases:     map[string]string{"alias": unique2Char[0]},
+	}
+
+	for i := maxRoles - 1; i >= 0; i-- {
+		ro := role{
+			Name:        fmt.Sprintf("role-%02d", i),
+			Description: strings.Repeat("role ", 1000),
+		}
+		if i%5 == 0 {
+			ro.Resources = append(ro.Resources, resource{Name: "*", Actions: []string{"GET"}})
+		}
+		for j, name := range c.Resources {
+			ro.Resources = append(ro.Resources, resource{
+				Name:        name,
+				Description: strings.Repeat("resource ", 100),
+				Actions:     httpActions[:(i+j)%(maxActions+1)],
+			})
+		}
+		ro.Resources = append(ro.Resources, resource{Name: "alias", Actions: []string{"DELETE"}})
+		c.Roles = append(c.Roles, ro)
+	}
+
+	c.Deny = []deny{
+		{Role: "role-00", Resource: unique2Char[1], Action: "GET"},
+		{Role: "role-07", Resource: "*", Action: "POST"},
+		{Role: "role-13", Resource: "alias", Action: "DELETE"},
+	}
+
+	return c
+}
+
+func Test_NewFromJsonConfigStream(t *testing.T) {
+	data, err := json.Marshal(largeConfig())
+	require.NoError(t, err)
+
+	f, _ := os.CreateTemp(".", "*.json")
+	defer os.Remove(f.Name())
+	f.Write(data)
+
+	want, err := NewFromJsonConfig(f.Name())
+	require.NoError(t, err)
+
+	got, err := NewFromJsonConfigStream(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	t.Run("repeated roles key", func(t *testing.T) {
+		f, _ := os.CreateTemp(".", "*.json")
+		defer os.Remove(f.Name())
+		f.Write([]byte(`{
+			"roles": [{"name": "X", "resources": [{"name": "instances", "actions": ["GET"]}]}],
+			"resources": ["instances"],
+			"Roles": [{"name": "Y", "resources": [{"name": "instances", "actions": ["POST"]}]}]
+		}`))
+
+		want, err := NewFromJsonConfig(f.Name())
+		require.NoError(t, err)
+
+		got, err := NewFromJsonConfigStream(f.Name())
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+		assert.False(t, got.HasRole("X"))
+		assert.True(t, got.HasRole("Y"))
+	})
+
+	t.Run("fixture", func(t *testing.T) {
+		f, _ := os.CreateTemp(".", "*.json")
+		defer os.Remove(f.Name())
+		f.Write([]byte(rolesJson))
+
+		want, err := NewFromJsonConfig(f.Name())
+		require.NoError(t, err)
+
+		got, err := NewFromJsonConfigStream(f.Name())
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	})
+}
+
+func Test_NewFromJsonConfigStream_errors(t *testing.T) {
+	moreThanMaxRoles := make([]string, maxRoles+1)
+	for i := range moreThanMaxRoles {
+		moreThanMaxRoles[i] = fmt.Sprintf(`{"name": "role-%02d", "resources": [{"name": "instances", "actions": ["GET"]}]}`, i)
+	}
+
+	tests := []struct {
+		name        string
+		jsonContent string
+		opts        []Option
+	}{
+		{
+			name:        "invalid json config",
+			jsonContent: " invalid json ",
+		},
+		{
+			name:        "trailing data",
+			jsonContent: `{"resources": ["instances"], "roles": [{"name": "Admin", "resources": [{"name": "*", "actions": ["GET"]}]}]} garbage`,
+		},
+		{
+			name:        "trailing value",
+			jsonContent: `{"resources": ["instances"], "roles": [{"name": "Admin", "resources": [{"name": "*", "actions": ["GET"]}]}]} {}`,
+		},
+		{
+			name:        "invalid role",
+			jsonContent: `{"resources": ["instances"], "roles": [{"name": 1}]}`,
+		},
+		{
+			name:        "no resources",
+			jsonContent: `{"resources": []}`,
+		},
+		{
+			name:        "empty resource in strict mode",
+			jsonContent: `{"resources": ["instances", ""], "roles": [{"name": "Admin", "resources": [{"name": "*", "actions": ["GET"]}]}]}`,
+			opts:        []Option{WithStrictResources()},
+		},
+		{
+			name:        "no roles",
+			jsonContent: `{"roles": null, "resources": ["instances"]}`,
+		},
+		{
+			name:        "undefined resource",
+			jsonContent: `{"resources": ["instances"], "roles": [{"name": "Admin", "resources": [{"name": "storage", "actions": ["GET"]}]}]}`,
+		},
+		{
+			name: "unknown action before undefined resource",
+			jsonContent: `{"resources": ["instances"], "roles": [
+				{"name": "X", "resources": [{"name": "instances", "actions": ["TRACE"]}]},
+				{"name": "Y", "resources": [{"name": "zz", "actions": ["GET"]}]}]}`,
+		},
+		{
+			name:        "roles before invalid resources",
+			jsonContent: `{"roles": [{"name": "", "resources": []}], "resources": []}`,
+		},
+		{
+			name: "invalid roles under repeated key",
+			jsonContent: `{"resources": ["instances"], "roles": [{"name": "X", "resources": [{"name": "zz", "actions": ["GET"]}]}],
+				"Roles": [{"name": "Y", "resources": [{"name": "instances", "actions": ["TRACE"]}]}]}`,
+		},
+		{
+			name:        "roles exceeded",
+			jsonContent: `{"resources": ["instances"], "roles": [` + strings.Join(moreThanMaxRoles, ",") + `]}`,
+		},
+		{
+			name:        "unknown action",
+			jsonContent: `{"resources": ["instances"], "roles": [{"name": "Admin", "resources": [{"name": "instances", "actions": ["TRACE"]}]}]}`,
+		},
+		{
+			name: "unknown deny action",
+			jsonContent: `{"resources": ["instances"], "roles": [{"name": "Admin", "resources": [{"name": "*", "actions": ["GET"]}]}],
+				"deny": [{"role": "Admin", "resource": "instances", "action": "TRACE"}]}`,
+		},
+		{
+			name: "contradictory deny",
+			jsonContent: `{"resources": ["instances"], "roles": [{"name": "Admin", "resources": [{"name": "*", "actions": ["GET"]}]}],
+				"deny": [{"role": "Admin", "resource": "*", "action": "GET"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := os.CreateTemp(".", "*.json")
+			defer os.Remove(f.Name())
+			f.Write([]byte(tt.jsonContent))
+
+			_, wantErr := NewFromJsonConfig(f.Name(), tt.opts...)
+			require.Error(t, wantErr)
+
+			r, err := NewFromJsonConfigStream(f.Name(), tt.opts...)
+			require.Error(t, err)
+			require.Nil(t, r)
+			if strings.HasPrefix(wantErr.Error(), "read config") {
+				// Decoding errors differ between json.Unmarshal and json.Decoder.
+				assert.Contains(t, err.Error(), "read config: unmarshal json config")
+			} else {
+				assert.Equal(t, wantErr.Error(), err.Error())
+			}
+		})
+	}
+
+	t.Run("roles exceeded early", func(t *testing.T) {
+		roles := make([]string, maxRoles+10)
+		for i := range roles {
+			roles[i] = fmt.Sprintf(`{"name": "role-%02d", "resources": [{"name": "instances", "actions": ["GET"]}]}`, i)
+		}
+		// The last role is invalid, but validation stops at the first exceeding role.
+		roles = append(roles, `{"name": ""}`)
+
+		f, _ := os.CreateTemp(".", "*.json")
+		defer os.Remove(f.Name())
+		f.Write([]byte(`{"resources": ["instances"], "roles": [` + strings.Join(roles, ",") + `]}`))
+
+		r, err := NewFromJsonConfigStream(f.Name())
+		require.Error(t, err)
+		assert.Equal(t, fmt.Sprintf("validate config: roles exceeded: maximum %d but config has %d", maxRoles, maxRoles+1), err.Error())
+		require.Nil(t, r)
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		r, err := NewFromJsonConfigStream("nonexistent.json")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "read config: open json config")
+		require.Nil(t, r)
+	})
+}
@@ -1,8 +1,15 @@
 package tinyrbac
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"path/filepath"
 	"slices"
+
+	"gopkg.in/yaml.v3"
 )
 
 // The access information is stored as follows:
@@ -29,44 +36,141 @@ type Rbac struct {
 	accessMap      [maxActions * maxRoles]resourceSet
 	roleIdxMap     [maxRoles]string
 	resourceIdxMap [maxResources]string
+
+	// Descriptions are only meant for humans and play no part in
+	// access checks. They are retained so that an exported config
+	// does not lose the documentation of the config it was built from.
+	description          string
+	roleDescriptions     [maxRoles]string
+	resourceDescriptions map[roleResource]string
+
+	// actionOffset maps an action to its offset in the access map.
+	// The HTTP method mapping is used when it is nil. actionIdxMap
+	// holds the known actions at their offsets.
+	actionOffset func(action string) (int, bool)
+	actionIdxMap [maxActions]string
+
+	// aliases maps alternative resource names to the
+	// resources whose bits they share.
+	aliases map[string]string
+}
+
+// roleResource identifies a resource entry of a role.
+type roleResource struct {
+	role     string
+	resource string
 }
 
 // NewFromJsonConfig creates an RBAC instance from a JSON config
 // file at the given path. An error is returned when the config
 // file cannot be proccessed.
-func NewFromJsonConfig(path string) (*Rbac, error) {
+func NewFromJsonConfig(path string, opts ...Option) (*Rbac, error) {
 	c, err := newConfigFromJson(path)
 	if err != nil {
 		return nil, fmt.Errorf("read config: %w", err)
 	}
-	if err := c.validate(); err != nil {
-		return nil, fmt.Errorf("validate config: %w", err)
-	}
 
-	return buildFromConfig(c)
+	return newFromConfig(c, newOptions(opts))
 }
 
 // NewFromJsonConfig creates an RBAC instance from a YAML config
 // file at the given path. An error is returned when the config
 // file cannot be proccessed.
-func NewFromYamlConfig(path string) (*Rbac, error) {
+func NewFromYamlConfig(path string, opts ...Option) (*Rbac, error) {
 	c, err := newConfigFromYaml(path)
 	if err != nil {
 		return nil, fmt.Errorf("read config: %w", err)
 	}
+
+	return newFromConfig(c, newOptions(opts))
+}
+
+// NewFromFirstAvailable creates an RBAC instance from the first of the given
+// config files that can be loaded and validated. The config type is derived
+// from the file extension, which is one of .json, .yaml or .yml.
+//
+// When none of the files can be used, the returned error wraps
+// ErrNoConfigAvailable along with the error of each path. Errors of
+// missing files wrap fs.ErrNotExist so they can be told apart from
+// parse and validation errors.
+func NewFromFirstAvailable(paths ...string) (*Rbac, error) {
+	return NewFromFirstAvailableWithOptions(paths)
+}
+
+// NewFromFirstAvailableWithOptions is NewFromFirstAvailable with options,
+// which are used for every file that is tried.
+func NewFromFirstAvailableWithOptions(paths []string, opts ...Option) (*Rbac, error) {
+	errs := []error{ErrNoConfigAvailable}
+	for _, path := range paths {
+		var (
+			r   *Rbac
+			err error
+		)
+		switch filepath.Ext(path) {
+		case ".json":
+			r, err = NewFromJsonConfig(path, opts...)
+		case ".yaml", ".yml":
+			r, err = NewFromYamlConfig(path, opts...)
+		default:
+			err = errConfigFiletypeUnsupported(path)
+		}
+		if err == nil {
+			return r, nil
+		}
+		// Missing files are expected when looking up layered
+		// configs, they are skipped but still reported below.
+		if errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("skip %q: %w", path, err))
+			continue
+		}
+		errs = append(errs, fmt.Errorf("load %q: %w", path, err))
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// newFromConfig validates the config and builds an RBAC instance from it.
+func newFromConfig(c *config, o options) (*Rbac, error) {
+	if o.strictResources {
+		if err := c.validateResourceNames(); err != nil {
+			return nil, fmt.Errorf("validate config: %w", err)
+		}
+	}
 	if err := c.validate(); err != nil {
 		return nil, fmt.Errorf("validate config: %w", err)
 	}
+	if err := c.validateDenyActions(o.actionOffset); err != nil {
+		return nil, fmt.Errorf("validate config: %w", err)
+	}
 
-	return buildFromConfig(c)
+	return buildFromConfig(c, o)
 }
 
-// buildRoleAndResourceMapping extracts roles and resources from config.
+// newRbac creates an RBAC instance without any roles or grants from config.
+func newRbac(c *config, o options) *Rbac {
+	r := &Rbac{
+		description:          c.Description,
+		resourceDescriptions: make(map[roleResource]string),
+		actionOffset:         o.actionOffset,
+		aliases:              maps.Clone(c.Aliases),
+	}
+	if r.actionOffset == nil {
+		r.actionIdxMap = httpActions
+	}
+	buildResourceMapping(c, r)
+
+	return r
+}
+
+// buildResourceMapping extracts resources from config.
 // The extracted information is stored in a sorted manner which allows for
 // the core idea of using the role-index and resource-index mapping to perform rbac operations.
-func buildRoleAndResourceMapping(c *config, r *Rbac) {
+func buildResourceMapping(c *config, r *Rbac) {
 	resources := make(map[string]bool)
 	for _, r := range c.Resources {
+		if r == "" {
+			continue
+		}
 		resources[r] = true
 	}
 
@@ -77,10 +181,14 @@ func buildRoleAndResourceMapping(c *config, r *Rbac) {
 	}
 	// Sorting because Go maps do not store/return data in an ordered fashion.
 	slices.Sort(r.resourceIdxMap[:i])
+}
 
+// buildRoleMapping extracts roles from config in a sorted manner,
+// the same way as buildResourceMapping does for resources.
+func buildRoleMapping(c *config, r *Rbac) {
 	// Config validation makes sure roles are unique. So a map
 	// filtering is not needed.
-	i = 0
+	i := 0
 	for _, role := range c.Roles {
 		r.roleIdxMap[i] = role.Name
 		i++
@@ -94,36 +202,106 @@ func buildRoleAndResourceMapping(c *config, r *Rbac) {
 }
 
 // buildFromConfig builds the actual access map from config.
-func buildFromConfig(c *config) (*Rbac, error) {
-	r := &Rbac{}
-	buildRoleAndResourceMapping(c, r)
+func buildFromConfig(c *config, o options) (*Rbac, error) {
+	r := newRbac(c, o)
+	buildRoleMapping(c, r)
 
 	for _, role := range c.Roles {
-		accessIdx := slices.Index(r.roleIdxMap[:], role.Name) * maxActions
-		for _, resource := range role.Resources {
-			// If no actions are provided for a resource it can be ignored.
-			// TODO: Should this be moved to config validation?
-			actions := slices.DeleteFunc(resource.Actions, func(a string) bool {
-				return a == ""
-			})
-			if len(actions) == 0 {
-				continue
+		if err := r.buildRole(slices.Index(r.roleIdxMap[:], role.Name), role); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.buildDeny(c.Deny); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// buildRole adds the grants of 'role' to the access map at 'roleIdx'.
+func (r *Rbac) buildRole(roleIdx int, role role) error {
+	r.roleDescriptions[roleIdx] = role.Description
+
+	accessIdx := roleIdx * maxActions
+	for _, resource := range role.Resources {
+		resourceName := r.resolveResource(resource.Name)
+		if resource.Description != "" {
+			r.resourceDescriptions[roleResource{role.Name, resourceName}] = resource.Description
+		}
+
+		// If no actions are provided for a resource it can be ignored.
+		// TODO: Should this be moved to config validation?
+		actions := slices.DeleteFunc(resource.Actions, func(a string) bool {
+			return a == ""
+		})
+		if len(actions) == 0 {
+			continue
+		}
+
+		for _, action := range actions {
+			offset, err := r.getActionOffset(action)
+			if err != nil {
+				return fmt.Errorf("build role %s: %w", role.Name, err)
 			}
+			r.actionIdxMap[offset] = action
 
 			if resource.Name == allResources {
-				for _, action := range actions {
-					r.accessMap[accessIdx+getHTTPActionOffset(action)] = allResourceAccess
-				}
+				r.accessMap[accessIdx+offset] = allResourceAccess
 			} else {
-				resourceIdx := slices.Index(r.resourceIdxMap[:], resource.Name)
-				for _, action := range actions {
-					r.accessMap[accessIdx+getHTTPActionOffset(action)] |= 1 << resourceIdx
-				}
+				resourceIdx := slices.Index(r.resourceIdxMap[:], resourceName)
+				r.accessMap[accessIdx+offset] |= 1 << resourceIdx
 			}
 		}
 	}
 
-	return r, nil
+	return nil
+}
+
+// buildDeny removes the denied access from the access map. Denies are
+// applied last so that they override every grant, including the ones
+// given on all the resources.
+func (r *Rbac) buildDeny(denies []deny) error {
+	for i, d := range denies {
+		offset, err := r.getActionOffset(d.Action)
+		if err != nil {
+			return fmt.Errorf("build deny at index %d: %w", i, err)
+		}
+		r.actionIdxMap[offset] = d.Action
+
+		accessIdx := slices.Index(r.roleIdxMap[:], d.Role)*maxActions + offset
+		if d.Resource == allResources {
+			r.accessMap[accessIdx] = 0
+		} else {
+			resourceIdx := slices.Index(r.resourceIdxMap[:], r.resolveResource(d.Resource))
+			r.accessMap[accessIdx] &^= 1 << resourceIdx
+		}
+	}
+
+	return nil
+}
+
+// getActionOffset returns the offset of 'action' in the access map. An error is
+// returned for unknown actions, out of range offsets and offsets that are
+// already taken by another known action.
+func (r *Rbac) getActionOffset(action string) (int, error) {
+	actionOffset := r.actionOffset
+	if actionOffset == nil {
+		actionOffset = httpActionOffset
+	}
+
+	offset, ok := actionOffset(action)
+	if !ok {
+		return 0, fmt.Errorf("unknown action: %s", action)
+	}
+	if offset < 0 || offset >= maxActions {
+		return 0, fmt.Errorf("action offset out of range: %s maps to %d but maximum is %d", action, offset, maxActions-1)
+	}
+	// Distinct actions sharing an offset would silently share grants.
+	if known := r.actionIdxMap[offset]; known != "" && known != action {
+		return 0, errActionOffsetCollision(known, action, offset)
+	}
+	return offset, nil
 }
 
 // TODO: Justify linearly searching instead of using a hash map.
@@ -139,8 +317,9 @@ func (r *Rbac) check(role, resource, action string) (bool, error) {
 		return false, fmt.Errorf("unknown role: %s", role)
 	}
 
+	resolved := r.resolveResource(resource)
 	for idx, resourceName := range r.resourceIdxMap {
-		if resourceName == resource {
+		if resourceName == resolved {
 			resourceIdx = idx
 			break
 		}
@@ -149,12 +328,123 @@ func (r *Rbac) check(role, resource, action string) (bool, error) {
 		return false, fmt.Errorf("unknown resource: %s", resource)
 	}
 
-	accessIdx := roleIdx*maxActions + getHTTPActionOffset(action)
+	offset, err := r.getActionOffset(action)
+	if err != nil {
+		return false, err
+	}
+
+	accessIdx := roleIdx*maxActions + offset
 	return r.accessMap[accessIdx]&resourceSet(1<<resourceIdx) != 0, nil
 }
 
+// HasRole reports whether 'role' is defined in the RBAC instance.
+// An empty role name is never considered defined.
+func (r *Rbac) HasRole(role string) bool {
+	return role != "" && slices.Contains(r.roleIdxMap[:], role)
+}
+
+// HasResource reports whether 'resource' is defined in the RBAC instance,
+// either as a resource or as an alias of one. An empty resource name is
+// never considered defined.
+func (r *Rbac) HasResource(resource string) bool {
+	return resource != "" && slices.Contains(r.resourceIdxMap[:], r.resolveResource(resource))
+}
+
+// resolveResource returns the resource 'name' is an alias of,
+// or 'name' itself when it is not an alias.
+func (r *Rbac) resolveResource(name string) string {
+	if target, ok := r.aliases[name]; ok {
+		return target
+	}
+	return name
+}
+
+// Complement returns, per resource, the known actions 'role' does not have access to.
+// Resources on which the role has access to every action are left out, so the
+// complement of a role with full access is empty. An error is returned for an
+// unknown role.
+func (r *Rbac) Complement(role string) (map[string][]string, error) {
+	roleIdx := slices.Index(r.roleIdxMap[:], role)
+	if role == "" || roleIdx == -1 {
+		return nil, fmt.Errorf("unknown role: %s", role)
+	}
+
+	// Only the bits backed by a resource are of interest. The remaining
+	// bits of the complement are set but do not represent anything.
+	var validResources resourceSet
+	for idx, resource := range r.resourceIdxMap {
+		if resource != "" {
+			validResources |= 1 << idx
+		}
+	}
+
+	complement := make(map[string][]string)
+	for offset, action := range r.actionIdxMap {
+		if action == "" {
+			continue
+		}
+		denied := ^r.accessMap[roleIdx*maxActions+offset] & validResources
+		for idx, resource := range r.resourceIdxMap {
+			if denied&(1<<idx) != 0 {
+				complement[resource] = append(complement[resource], action)
+			}
+		}
+	}
+
+	return complement, nil
+}
+
+// Grant gives 'role' access to perform 'action' on 'resource'. The wildcard
+// resource grants access on all the resources. An error is returned when the
+// role, resource or action is unknown. Grant must not be called concurrently
+// with other methods of the RBAC instance.
+func (r *Rbac) Grant(role, resource, action string) error {
+	roleIdx := slices.Index(r.roleIdxMap[:], role)
+	if role == "" || roleIdx == -1 {
+		return fmt.Errorf("unknown role: %s", role)
+	}
+
+	resourceIdx := slices.Index(r.resourceIdxMap[:], r.resolveResource(resource))
+	if resource != allResources && (resource == "" || resourceIdx == -1) {
+		return fmt.Errorf("unknown resource: %s", resource)
+	}
+
+	offset, err := r.getActionOffset(action)
+	if err != nil {
+		return err
+	}
+	r.actionIdxMap[offset] = action
+
+	accessIdx := roleIdx*maxActions + offset
+	if resource == allResources {
+		r.accessMap[accessIdx] = allResourceAccess
+	} else {
+		r.accessMap[accessIdx] |= 1 << resourceIdx
+	}
+
+	return nil
+}
+
+// Reset removes all the grants while keeping the roles, resources and
+// known actions, so that access can be given again using Grant. Reset
+// must not be called concurrently with other methods of the RBAC instance.
+func (r *Rbac) Reset() {
+	r.accessMap = [maxActions * maxRoles]resourceSet{}
+}
+
 // Check returns (true, nil) if 'role' has access to perform 'action' on 'resource'
 // and (false, nil) otheriwse. In case of an error false is returned along with the error.
 func (r *Rbac) Check(role, resource, action string) (bool, error) {
 	return r.check(role, resource, action)
 }
+
+// WriteYamlConfig writes the policy of the RBAC instance to 'w' as a YAML
+// config that can be loaded again with NewFromYamlConfig. Descriptions of
+// the config, roles and role resources are preserved.
+func (r *Rbac) WriteYamlConfig(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	if err := enc.Encode(r.toConfig()); err != nil {
+		return fmt.Errorf("encode yaml config: %w", err)
+	}
+	return enc.Close()
+}
@@ -1,6 +1,8 @@
 package tinyrbac
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"testing"
 
@@ -9,6 +11,7 @@ import (
 )
 
 const roles = 3
+const resourceCount = 3
 const rolesJson = `{
   "resources": ["instances", "applications", "audit-logs"],
   "roles": [
@@ -280,6 +283,14 @@ func Test_Check(t *testing.T) {
 			expectedAccess: false,
 			expectedError:  "unknown resource: orders",
 		},
+		{
+			name:           "action not found",
+			role:           "Admin",
+			resource:       "instances",
+			action:         "TRACE",
+			expectedAccess: false,
+			expectedError:  "unknown action: TRACE",
+		},
 	}
 
 	r, err := NewFromJsonConfig(f.Name())
@@ -298,3 +309,729 @@ func Test_Check(t *testing.T) {
 		})
 	}
 }
+
+func Test_HasRole(t *testing.T) {
+	f, _ := os.CreateTemp(".", "*.json")
+	defer os.Remove(f.Name())
+	f.Write([]byte(rolesJson))
+
+	r, err := NewFromJsonConfig(f.Name())
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name     string
+		role     string
+		expected bool
+	}{
+		{name: "known role", role: "Instance Manager", expected: true},
+		{name: "unknown role", role: "Operator", expected: false},
+		{name: "empty role", role: "", expected: false},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, r.HasRole(tt.role))
+		})
+	}
+}
+
+func Test_HasResource(t *testing.T) {
+	f, _ := os.CreateTemp(".", "*.json")
+	defer os.Remove(f.Name())
+	f.Write([]byte(rolesJson))
+
+	r, err := NewFromJsonConfig(f.Name())
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name     string
+		resource string
+		expected bool
+	}{
+		{name: "known resource", resource: "audit-logs", expected: true},
+		{name: "unknown resource", resource: "orders", expected: false},
+		{name: "empty resource", resource: "", expected: false},
+		{name: "wildcard resource", resource: allResources, expected: false},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, r.HasResource(tt.resource))
+		})
+	}
+}
+
+func Test_WriteYamlConfig(t *testing.T) {
+	const describedYaml = `
+description: "Policy for the instance service"
+resources:
+- "instances"
+- "applications"
+- "audit-logs"
+roles:
+  - name: Admin
+    description: "Full access to everything"
+    resources:
+      - name: "*"
+        description: "Admins are trusted with every resource"
+        actions: [GET, POST, PUT, PATCH, DELETE]
+      - name: instances
+        description: "Kept when covered by the wildcard"
+        actions: [GET]
+  - name: Auditor
+    description: "Read only access to logs"
+    resources:
+      - name: audit-logs
+        description: "Needed for compliance reviews"
+        actions: [GET]
+      - name: applications
+        actions: [GET]
+      - name: instances
+        description: "Kept without any actions"
+        actions: [""]
+  - name: Guest
+    resources:
+      - name: applications
+        actions: [GET]
+`
+	f, _ := os.CreateTemp(".", "*.yaml")
+	defer os.Remove(f.Name())
+	f.Write([]byte(describedYaml))
+
+	r, err := NewFromYamlConfig(f.Name())
+	require.NoError(t, err)
+
+	out, _ := os.CreateTemp(".", "*.yaml")
+	defer os.Remove(out.Name())
+	require.NoError(t, r.WriteYamlConfig(out))
+	out.Close()
+
+	c, err := newConfigFromYaml(out.Name())
+	require.NoError(t, err)
+	assert.Equal(t, "Policy for the instance service", c.Description)
+	assert.Equal(t, []role{
+		{
+			Name:        "Admin",
+			Description: "Full access to everything",
+			Resources: []resource{
+				{
+					Name:        "*",
+					Description: "Admins are trusted with every resource",
+					Actions:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+				},
+				{
+					Name:        "instances",
+					Description: "Kept when covered by the wildcard",
+					Actions:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+				},
+			},
+		},
+		{
+			Name:        "Auditor",
+			Description: "Read only access to logs",
+			Resources: []resource{
+				{Name: "applications", Actions: []string{"GET"}},
+				{Name: "audit-logs", Description: "Needed for compliance reviews", Actions: []string{"GET"}},
+				{Name: "instances", Description: "Kept without any actions", Actions: []string{}},
+			},
+		},
+		{
+			Name: "Guest",
+			Resources: []resource{
+				{Name: "applications", Actions: []string{"GET"}},
+			},
+		},
+	}, c.Roles)
+
+	// Missing descriptions are left out instead of exported as empty.
+	data, err := os.ReadFile(out.Name())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `description: ""`)
+
+	reloaded, err := NewFromYamlConfig(out.Name())
+	require.NoError(t, err)
+	assert.Equal(t, r, reloaded)
+}
+
+func Test_WriteYamlConfig_rolesWithoutAccess(t *testing.T) {
+	const noAccessYaml = `
+resources:
+- "instances"
+- "applications"
+roles:
+  - name: Admin
+    resources:
+      - name: "*"
+        actions: [GET]
+  - name: Pending
+    resources:
+      - name: instances
+        actions: []
+  - name: Revoked
+    resources:
+      - name: applications
+        actions: [GET]
+deny:
+  - role: Revoked
+    resource: applications
+    action: GET
+`
+	// roundTrip exports 'r' and loads the export again.
+	roundTrip := func(t *testing.T, r *Rbac) *Rbac {
+		out, _ := os.CreateTemp(".", "*.yaml")
+		defer os.Remove(out.Name())
+		require.NoError(t, r.WriteYamlConfig(out))
+		out.Close()
+
+		reloaded, err := NewFromYamlConfig(out.Name())
+		require.NoError(t, err)
+		return reloaded
+	}
+
+	f, _ := os.CreateTemp(".", "*.yaml")
+	defer os.Remove(f.Name())
+	f.Write([]byte(noAccessYaml))
+
+	r, err := NewFromYamlConfig(f.Name())
+	require.NoError(t, err)
+
+	t.Run("roles without grants", func(t *testing.T) {
+		assert.Equal(t, r, roundTrip(t, r))
+	})
+
+	t.Run("after reset", func(t *testing.T) {
+		r.Reset()
+
+		reloaded := roundTrip(t, r)
+		assert.Equal(t, r, reloaded)
+		assert.Equal(t, []string{"Admin", "Pending", "Revoked"}, reloaded.roleIdxMap[:3])
+	})
+}
+
+func Test_Complement(t *testing.T) {
+	f, _ := os.CreateTemp(".", "*.json")
+	defer os.Remove(f.Name())
+	f.Write([]byte(rolesJson))
+
+	r, err := NewFromJsonConfig(f.Name())
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name          string
+		role          string
+		expected      map[string][]string
+		expectedError string
+	}{
+		{
+			name:     "role with access to everything",
+			role:     "Admin",
+			expected: map[string][]string{},
+		},
+		{
+			name: "role with partial access",
+			role: "Auditor",
+			expected: map[string][]string{
+				"applications": {"POST", "PUT", "PATCH", "DELETE"},
+				"audit-logs":   {"POST", "PUT", "PATCH", "DELETE"},
+				"instances":    {"GET", "POST", "PUT", "PATCH", "DELETE"},
+			},
+		},
+		{
+			name:          "role not found",
+			role:          "Operator",
+			expectedError: "unknown role: Operator",
+		},
+		{
+			name:          "empty role",
+			role:          "",
+			expectedError: "unknown role: ",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			complement, err := r.Complement(tt.role)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				assert.Nil(t, complement)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, complement)
+		})
+	}
+}
+
+func Test_WithStrictResources(t *testing.T) {
+	const emptyResourceJson = `{
+  "resources": ["instances", "applications", "audit-logs", ""],
+  "roles": [
+    {
+      "name": "Auditor",
+      "resources": [
+        {
+          "name": "audit-logs",
+          "actions": ["GET"]
+        }
+      ]
+    }
+  ]
+}`
+
+	tests := []struct {
+		name        string
+		opts        []Option
+		wantErr     bool
+		expectedErr string
+	}{
+		{
+			name:    "lenient mode skips empty resource",
+			opts:    nil,
+			wantErr: false,
+		},
+		{
+			name:        "strict mode rejects empty resource",
+			opts:        []Option{WithStrictResources()},
+			wantErr:     true,
+			expectedErr: "validate config: empty resource name at index 3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := os.CreateTemp(".", "*.json")
+			defer os.Remove(f.Name())
+			f.Write([]byte(emptyResourceJson))
+
+			r, err := NewFromJsonConfig(f.Name(), tt.opts...)
+
+			if tt.wantErr == false {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"applications", "audit-logs", "instances", ""}, r.resourceIdxMap[:4])
+				assert.False(t, r.HasResource(""))
+
+				access, err := r.Check("Auditor", "audit-logs", "GET")
+				require.NoError(t, err)
+				assert.True(t, access)
+			} else {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedErr, err.Error())
+				require.Nil(t, r)
+			}
+		})
+	}
+}
+
+func Test_NewFromFirstAvailable(t *testing.T) {
+	invalid, _ := os.CreateTemp(".", "*.yaml")
+	defer os.Remove(invalid.Name())
+	invalid.Write([]byte("resources:"))
+
+	valid, _ := os.CreateTemp(".", "*.json")
+	defer os.Remove(valid.Name())
+	valid.Write([]byte(rolesJson))
+
+	const missing = "nonexistent.yaml"
+
+	t.Run("first valid config is used", func(t *testing.T) {
+		r, err := NewFromFirstAvailable(missing, invalid.Name(), valid.Name())
+		require.NoError(t, err)
+		assert.True(t, r.HasRole("Auditor"))
+	})
+
+	t.Run("no valid config", func(t *testing.T) {
+		r, err := NewFromFirstAvailable(missing, invalid.Name(), "config.toml")
+		require.Error(t, err)
+		require.Nil(t, r)
+		assert.ErrorIs(t, err, ErrNoConfigAvailable)
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		assert.Contains(t, err.Error(), `skip "nonexistent.yaml": read config: open yaml config`)
+		assert.Contains(t, err.Error(), `load "`+invalid.Name()+`": validate config: `+ErrNoResources.Error())
+		assert.Contains(t, err.Error(), `load "config.toml": unsupported config file type "config.toml"`)
+
+		// Only the missing file error is a not exist error.
+		var notExist []error
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			if errors.Is(e, fs.ErrNotExist) {
+				notExist = append(notExist, e)
+			}
+		}
+		assert.Len(t, notExist, 1)
+	})
+
+	t.Run("options are used for every config", func(t *testing.T) {
+		emptyResource, _ := os.CreateTemp(".", "*.json")
+		defer os.Remove(emptyResource.Name())
+		emptyResource.Write([]byte(`{"resources": ["instances", ""], "roles": [{"name": "Lenient", "resources": [{"name": "instances", "actions": ["GET"]}]}]}`))
+
+		r, err := NewFromFirstAvailableWithOptions([]string{emptyResource.Name(), valid.Name()})
+		require.NoError(t, err)
+		assert.True(t, r.HasRole("Lenient"))
+
+		r, err = NewFromFirstAvailableWithOptions([]string{emptyResource.Name(), valid.Name()}, WithStrictResources())
+		require.NoError(t, err)
+		assert.False(t, r.HasRole("Lenient"))
+		assert.True(t, r.HasRole("Auditor"))
+	})
+
+	t.Run("no paths", func(t *testing.T) {
+		r, err := NewFromFirstAvailable()
+		require.Nil(t, r)
+		assert.ErrorIs(t, err, ErrNoConfigAvailable)
+	})
+}
+
+func Test_WithActionOffsetFunc(t *testing.T) {
+	const verbsJson = `{
+  "resources": ["instances", "audit-logs"],
+  "roles": [
+    {
+      "name": "Operator",
+      "resources": [
+        {
+          "name": "instances",
+          "actions": ["read", "write"]
+        },
+        {
+          "name": "audit-logs",
+          "actions": ["read"]
+        }
+      ]
+    }
+  ]
+}`
+	verbs := func(action string) (int, bool) {
+		switch action {
+		case "read":
+			return 0, true
+		case "write":
+			return 1, true
+		case "remove":
+			return 2, true
+		case "overflow":
+			return maxActions, true
+		default:
+			return 0, false
+		}
+	}
+
+	f, _ := os.CreateTemp(".", "*.json")
+	defer os.Remove(f.Name())
+	f.Write([]byte(verbsJson))
+
+	r, err := NewFromJsonConfig(f.Name(), WithActionOffsetFunc(verbs))
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name           string
+		resource       string
+		action         string
+		expectedAccess bool
+		expectedError  string
+	}{
+		{name: "granted action", resource: "instances", action: "write", expectedAccess: true},
+		{name: "granted action on other resource", resource: "audit-logs", action: "read", expectedAccess: true},
+		{name: "action not granted", resource: "audit-logs", action: "write", expectedAccess: false},
+		{name: "known action never granted", resource: "instances", action: "remove", expectedAccess: false},
+		{name: "http method is unknown", resource: "instances", action: "GET", expectedError: "unknown action: GET"},
+		{
+			name:          "offset out of range",
+			resource:      "instances",
+			action:        "overflow",
+			expectedError: "action offset out of range: overflow maps to 5 but maximum is 4",
+		},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			access, err := r.Check("Operator", tt.resource, tt.action)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedAccess, access)
+		})
+	}
+
+	t.Run("unknown action in config", func(t *testing.T) {
+		r, err := NewFromJsonConfig(f.Name())
+		require.Error(t, err)
+		assert.Equal(t, "build role Operator: unknown action: read", err.Error())
+		require.Nil(t, r)
+	})
+}
+
+func Test_ActionOffsetCollision(t *testing.T) {
+	const verbsJson = `{
+  "resources": ["instances"],
+  "roles": [
+    {
+      "name": "Operator",
+      "resources": [
+        {
+          "name": "instances",
+          "actions": ["read", "write"]
+        }
+      ]
+    }
+  ]
+}`
+	f, _ := os.CreateTemp(".", "*.json")
+	defer os.Remove(f.Name())
+	f.Write([]byte(verbsJson))
+
+	t.Run("colliding actions in config", func(t *testing.T) {
+		colliding := func(action string) (int, bool) {
+			return 0, action == "read" || action == "write"
+		}
+
+		r, err := NewFromJsonConfig(f.Name(), WithActionOffsetFunc(colliding))
+		require.Error(t, err)
+		assert.Equal(t, "build role Operator: action offset collision: read and write both map to 0", err.Error())
+		require.Nil(t, r)
+	})
+
+	t.Run("colliding action on check", func(t *testing.T) {
+		colliding := func(action string) (int, bool) {
+			switch action {
+			case "read", "list":
+				return 0, true
+			case "write":
+				return 1, true
+			default:
+				return 0, false
+			}
+		}
+
+		r, err := NewFromJsonConfig(f.Name(), WithActionOffsetFunc(colliding))
+		require.NoError(t, err)
+
+		access, err := r.Check("Operator", "instances", "list")
+		require.Error(t, err)
+		assert.Equal(t, "action offset collision: read and list both map to 0", err.Error())
+		assert.False(t, access)
+	})
+}
+
+func Test_Reset(t *testing.T) {
+	f, _ := os.CreateTemp(".", "*.json")
+	defer os.Remove(f.Name())
+	f.Write([]byte(rolesJson))
+
+	r, err := NewFromJsonConfig(f.Name())
+	require.NoError(t, err)
+
+	r.Reset()
+
+	assert.Equal(t, []string{"Admin", "Auditor", "Instance Manager"}, r.roleIdxMap[:roles])
+	assert.Equal(t, []string{"applications", "audit-logs", "instances"}, r.resourceIdxMap[:resourceCount])
+	for _, role := range r.roleIdxMap[:roles] {
+		for _, resource := range r.resourceIdxMap[:resourceCount] {
+			for _, action := range httpActions {
+				access, err := r.Check(role, resource, action)
+				require.NoError(t, err)
+				assert.False(t, access, "%s %s %s", role, resource, action)
+			}
+		}
+	}
+
+	require.NoError(t, r.Grant("Auditor", "instances", "GET"))
+	for _, role := range r.roleIdxMap[:roles] {
+		for _, resource := range r.resourceIdxMap[:resourceCount] {
+			for _, action := range httpActions {
+				access, err := r.Check(role, resource, action)
+				require.NoError(t, err)
+				expected := role == "Auditor" && resource == "instances" && action == "GET"
+				assert.Equal(t, expected, access, "%s %s %s", role, resource, action)
+			}
+		}
+	}
+}
+
+func Test_Grant(t *testing.T) {
+	f, _ := os.CreateTemp(".", "*.json")
+	defer os.Remove(f.Name())
+	f.Write([]byte(rolesJson))
+
+	r, err := NewFromJsonConfig(f.Name())
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name          string
+		role          string
+		resource      string
+		action        string
+		expectedError string
+	}{
+		{name: "grant on resource", role: "Auditor", resource: "instances", action: "PUT"},
+		{name: "grant on all resources", role: "Auditor", resource: "*", action: "DELETE"},
+		{name: "role not found", role: "Operator", resource: "instances", action: "GET", expectedError: "unknown role: Operator"},
+		{name: "resource not found", role: "Auditor", resource: "orders", action: "GET", expectedError: "unknown resource: orders"},
+		{name: "empty resource", role: "Auditor", resource: "", action: "GET", expectedError: "unknown resource: "},
+		{name: "action not found", role: "Auditor", resource: "instances", action: "TRACE", expectedError: "unknown action: TRACE"},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.Grant(tt.role, tt.resource, tt.action)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Equal(t, tt.expectedError, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			resources := []string{tt.resource}
+			if tt.resource == allResources {
+				resources = r.resourceIdxMap[:resourceCount]
+			}
+			for _, resource := range resources {
+				access, err := r.Check(tt.role, resource, tt.action)
+				require.NoError(t, err)
+				assert.True(t, access)
+			}
+		})
+	}
+}
+
+func Test_Deny(t *testing.T) {
+	const denyYaml = `
+resources:
+- "instances"
+- "applications"
+- "audit-logs"
+roles:
+  - name: Operator
+    resources:
+      - name: "*"
+        actions: [GET]
+      - name: instances
+        actions: [GET]
+      - name: audit-logs
+        actions: [POST]
+  - name: Auditor
+    resources:
+      - name: audit-logs
+        actions: [GET]
+deny:
+  - role: Operator
+    resource: instances
+    action: GET
+  - role: Operator
+    resource: "*"
+    action: POST
+  - role: Auditor
+    resource: audit-logs
+    action: DELETE
+`
+	f, _ := os.CreateTemp(".", "*.yaml")
+	defer os.Remove(f.Name())
+	f.Write([]byte(denyYaml))
+
+	r, err := NewFromYamlConfig(f.Name())
+	require.NoError(t, err)
+
+	testcases := []struct {
+		name           string
+		role           string
+		resource       string
+		action         string
+		expectedAccess bool
+	}{
+		{name: "specific and wildcard grant denied", role: "Operator", resource: "instances", action: "GET", expectedAccess: false},
+		{name: "wildcard grant on other resource kept", role: "Operator", resource: "applications", action: "GET", expectedAccess: true},
+		{name: "wildcard deny", role: "Operator", resource: "audit-logs", action: "POST", expectedAccess: false},
+		{name: "deny without grant", role: "Auditor", resource: "audit-logs", action: "DELETE", expectedAccess: false},
+		{name: "other action kept", role: "Auditor", resource: "audit-logs", action: "GET", expectedAccess: true},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			access, err := r.Check(tt.role, tt.resource, tt.action)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAccess, access)
+		})
+	}
+
+	t.Run("unknown deny action", func(t *testing.T) {
+		f, _ := os.CreateTemp(".", "*.yaml")
+		defer os.Remove(f.Name())
+		f.Write([]byte(`
+resources: ["instances"]
+roles:
+  - name: Auditor
+    resources:
+      - name: instances
+        actions: [GET]
+deny:
+  - role: Auditor
+    resource: instances
+    action: TRACE
+`))
+
+		r, err := NewFromYamlConfig(f.Name())
+		require.Error(t, err)
+		assert.Equal(t, "validate config: unknown action: TRACE for deny at index 0", err.Error())
+		require.Nil(t, r)
+	})
+}
+
+func Test_Aliases(t *testing.T) {
+	const aliasYaml = `
+resources:
+- "users"
+- "posts"
+aliases:
+  accounts: users
+  articles: posts
+roles:
+  - name: Support
+    resources:
+      - name: users
+        actions: [GET, PATCH]
+      - name: articles
+        actions: [GET]
+deny:
+  - role: Support
+    resource: accounts
+    action: PATCH
+`
+	f, _ := os.CreateTemp(".", "*.yaml")
+	defer os.Remove(f.Name())
+	f.Write([]byte(aliasYaml))
+
+	r, err := NewFromYamlConfig(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"posts", "users", ""}, r.resourceIdxMap[:3])
+
+	testcases := []struct {
+		name           string
+		resource       string
+		action         string
+		expectedAccess bool
+	}{
+		{name: "grant on resource checked via alias", resource: "accounts", action: "GET", expectedAccess: true},
+		{name: "grant on resource checked via resource", resource: "users", action: "GET", expectedAccess: true},
+		{name: "grant on alias checked via resource", resource: "posts", action: "GET", expectedAccess: true},
+		{name: "deny on alias applies to resource", resource: "users", action: "PATCH", expectedAccess: false},
+		{name: "action not granted", resource: "accounts", action: "DELETE", expectedAccess: false},
+	}
+
+	for _, tt := range testcases {
+		t.Run(tt.name, func(t *testing.T) {
+			access, err := r.Check("Support", tt.resource, tt.action)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedAccess, access)
+		})
+	}
+
+	assert.True(t, r.HasResource("accounts"))
+
+	require.NoError(t, r.Grant("Support", "accounts", "DELETE"))
+	access, err := r.Check("Support", "users", "DELETE")
+	require.NoError(t, err)
+	assert.True(t, access)
+}
@@ -2,6 +2,16 @@ package tinyrbac
 
 import "net/http"
 
+// httpActions maps an action offset back to its HTTP method.
+// It must be kept in sync with getHTTPActionOffset.
+var httpActions = [maxActions]string{
+	http.MethodGet,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
 func getHTTPActionOffset(action string) int {
 	switch action {
 	case http.MethodGet:
@@ -18,3 +28,10 @@ func getHTTPActionOffset(action string) int {
 		return unknownAction
 	}
 }
+
+// httpActionOffset is getHTTPActionOffset in the form of a custom
+// action offset function.
+func httpActionOffset(action string) (int, bool) {
+	offset := getHTTPActionOffset(action)
+	return offset, offset != unknownAction
+}